@@ -0,0 +1,34 @@
+// Package audit records privileged operations - role promotions,
+// deactivations, and database statements run under an elevated role - to a
+// pluggable sink, giving a tamper-evident trail suitable for compliance
+// review.
+package audit
+
+import (
+	"regexp"
+
+	"go-sample/models"
+)
+
+// Record describes a single privileged operation. It is an alias of
+// models.AuditRecord: models.User.Promote and models.User.Deactivate
+// require an Auditor directly, so the record type they build has to live in
+// package models rather than here: this package imports models, so models
+// importing audit would be an import cycle. The alias keeps every sink in this package (FileSink,
+// TableSink) written in terms of the familiar audit.Record name.
+type Record = models.AuditRecord
+
+// Auditor records privileged operations. Implementations must be safe for
+// concurrent use. It is an alias of models.Auditor; see Record for why.
+type Auditor = models.Auditor
+
+// literalPattern matches single-quoted string literals, numeric literals,
+// and positional/placeholder parameters ($1, ?) in a SQL statement.
+var literalPattern = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\?|\b\d+\b`)
+
+// Fingerprint reduces a SQL statement to its shape by replacing every
+// literal and placeholder with "?", so semantically identical statements
+// produce the same fingerprint regardless of the values bound to them.
+func Fingerprint(sqlText string) string {
+	return literalPattern.ReplaceAllString(sqlText, "?")
+}
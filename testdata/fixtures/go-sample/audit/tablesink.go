@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+)
+
+// Execer is satisfied by *database.Connection, letting TableSink write
+// audit records without this package importing database directly. Callers
+// typically pass the same Connection (or the transaction-scoped Connection
+// handed to a Transaction callback) used for the change being audited, so
+// the audit row commits or rolls back atomically with it.
+type Execer interface {
+	// ExecuteContext runs sqlText under ctx, so a caller's cancellation or
+	// deadline on Record bounds the audit insert too.
+	ExecuteContext(ctx context.Context, sqlText string, args ...interface{}) (int64, error)
+	// Placeholders returns n comma-separated bind-parameter placeholders in
+	// the syntax the underlying driver expects, so INSERT statements built
+	// here work against Postgres ($1, $2, ...) as well as MySQL/SQLite (?).
+	Placeholders(n int) string
+}
+
+// TableSink writes audit records to a dedicated table.
+type TableSink struct {
+	conn  Execer
+	table string
+}
+
+// NewTableSink returns a TableSink that inserts into table via conn. An
+// empty table defaults to "audit_log".
+func NewTableSink(conn Execer, table string) *TableSink {
+	if table == "" {
+		table = "audit_log"
+	}
+	return &TableSink{conn: conn, table: table}
+}
+
+// WithConn returns a copy of s that writes through conn instead of s's
+// original connection, so a caller can rebind an existing TableSink onto a
+// transaction-scoped Connection and have the audit insert commit or roll
+// back atomically with the rest of that transaction.
+func (s *TableSink) WithConn(conn Execer) *TableSink {
+	return &TableSink{conn: conn, table: s.table}
+}
+
+// Record implements Auditor.
+func (s *TableSink) Record(ctx context.Context, rec Record) error {
+	insert := fmt.Sprintf(
+		"INSERT INTO %s (actor, action, target, before_role, after_role, sql_fingerprint, recorded_at) VALUES (%s)",
+		s.table, s.conn.Placeholders(7),
+	)
+	_, err := s.conn.ExecuteContext(ctx, insert, rec.Actor, rec.Action, rec.Target, rec.BeforeRole, rec.AfterRole, rec.SQLFingerprint, rec.Timestamp)
+	if err != nil {
+		return fmt.Errorf("audit: inserting record: %w", err)
+	}
+	return nil
+}
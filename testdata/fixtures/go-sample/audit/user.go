@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+
+	"go-sample/models"
+)
+
+// WrapPromote calls user.Promote(ctx, auditor, actor, newRole). It is kept
+// for callers already depending on the audit package's naming; the actual
+// enforcement - refusing the promotion if auditor is nil or fails to
+// record it - lives in models.User.Promote itself, so it applies even to
+// callers that invoke user.Promote directly instead of through this
+// wrapper.
+func WrapPromote(ctx context.Context, auditor Auditor, actor string, user *models.User, newRole models.Role) error {
+	return user.Promote(ctx, auditor, actor, newRole)
+}
+
+// WrapDeactivate calls user.Deactivate(ctx, auditor, actor). See WrapPromote
+// for why the refusal behavior lives on models.User itself.
+func WrapDeactivate(ctx context.Context, auditor Auditor, actor string, user *models.User) error {
+	return user.Deactivate(ctx, auditor, actor)
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-sample/audit"
+	"go-sample/models"
+)
+
+// SetAuditor configures the Auditor that Execute and Transaction report to
+// whenever this Connection is scoped, via WithRole, to a role of at least
+// models.RoleModerator.
+func (c *Connection) SetAuditor(a audit.Auditor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auditor = a
+}
+
+// SetActor records the identity attributed to audit records produced by
+// this Connection.
+func (c *Connection) SetActor(actorID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actorID = actorID
+}
+
+// maybeAudit records action/sqlText via c.auditor when this Connection is
+// scoped to a privileged role. A failure to record is logged rather than
+// propagated: the statement has already run, so refusing it after the fact
+// would leave the caller unable to distinguish a failed audit sink from a
+// failed statement.
+func (c *Connection) maybeAudit(ctx context.Context, action, sqlText string) {
+	if c.auditor == nil || !c.role.HasPermission(models.RoleModerator) {
+		return
+	}
+
+	rec := audit.Record{
+		Actor:          c.actorID,
+		Action:         action,
+		Target:         string(c.role),
+		BeforeRole:     string(c.role),
+		AfterRole:      string(c.role),
+		SQLFingerprint: audit.Fingerprint(sqlText),
+		Timestamp:      time.Now(),
+	}
+	if err := c.auditor.Record(ctx, rec); err != nil {
+		log.Printf("audit: recording %s failed: %v", action, err)
+	}
+}
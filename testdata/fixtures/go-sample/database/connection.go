@@ -2,10 +2,16 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"sync"
+
+	"go-sample/audit"
+	"go-sample/database/dsn"
+	"go-sample/models"
 )
 
 const (
@@ -32,32 +38,85 @@ var (
 	ErrPoolExhausted = errors.New("connection pool exhausted")
 )
 
+// querier is satisfied by *sql.DB, *sql.Tx, and *sql.Conn, letting Query,
+// Execute, and statement preparation run identically whether or not a
+// Connection is currently scoped to a transaction or a pinned connection.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
 // Connection manages a database connection with query execution support.
 type Connection struct {
 	url        string
+	driverName string
 	poolSize   int
-	connected  bool
-	maxRetries int
-	mu         sync.RWMutex
+
+	db   *sql.DB
+	tx   *sql.Tx
+	conn *sql.Conn // non-nil when this Connection pins a single physical connection, e.g. via WithRole
+
+	defaultRole models.Role // from the x-role URL param; applied to every pool checkout
+	role        models.Role // role currently active on conn, if any
+
+	auditor audit.Auditor // records Execute/Transaction calls made under role >= models.RoleModerator
+	actorID string        // identity attributed to this Connection's audit records
+
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+
+	retryPolicy RetryPolicy
+
+	mu        sync.RWMutex
+	connected bool
 }
 
-// NewConnection creates a new database connection.
-func NewConnection(url string, poolSize int) (*Connection, error) {
-	if url == "" {
+// NewConnection creates a new database connection. The driver is selected
+// from rawURL's scheme (postgres://, mysql://, sqlite://) and the
+// connection is verified with a ping before it is returned.
+func NewConnection(rawURL string, poolSize int) (*Connection, error) {
+	if rawURL == "" {
 		return nil, &DatabaseError{Message: "database URL must not be empty"}
 	}
 	if poolSize < 1 {
 		poolSize = 1
 	}
 
+	driverName, dsn, err := resolveDriver(rawURL)
+	if err != nil {
+		return nil, &DatabaseError{Message: err.Error()}
+	}
+
+	defaultRole, err := parseDefaultRole(rawURL)
+	if err != nil {
+		return nil, &DatabaseError{Message: err.Error()}
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, &DatabaseError{Message: fmt.Sprintf("opening connection: %v", err)}
+	}
+	db.SetMaxOpenConns(poolSize)
+	db.SetMaxIdleConns(poolSize)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, &DatabaseError{Message: fmt.Sprintf("connecting to database: %v", err)}
+	}
+
 	conn := &Connection{
-		url:        url,
-		poolSize:   poolSize,
-		connected:  true,
-		maxRetries: maxRetries,
+		url:         rawURL,
+		driverName:  driverName,
+		db:          db,
+		poolSize:    poolSize,
+		connected:   true,
+		stmts:       make(map[string]*sql.Stmt),
+		retryPolicy: DefaultRetryPolicy(),
+		defaultRole: defaultRole,
 	}
 
-	log.Printf("connected to database (pool_size=%d)", poolSize)
+	log.Printf("connected to database %s (driver=%s, pool_size=%d)", conn.RedactedURL(), driverName, poolSize)
 	return conn, nil
 }
 
@@ -68,58 +127,271 @@ func (c *Connection) IsConnected() bool {
 	return c.connected
 }
 
-// Query executes a SQL query and returns the result rows as string slices.
-func (c *Connection) Query(sql string) ([]string, error) {
+// SetRetryPolicy replaces the backoff policy used by Query, Execute, and
+// Transaction on this Connection. MaxAttempts is clamped to at least 1, so a
+// caller-supplied policy can never turn Query/Execute/Transaction into a
+// silent no-op.
+func (c *Connection) SetRetryPolicy(policy RetryPolicy) {
+	policy.MaxAttempts = normalizeMaxAttempts(policy.MaxAttempts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryPolicy = policy
+}
+
+// querier returns the object that should execute statements for this
+// Connection: the transaction it is scoped to, then the pinned connection
+// from WithRole, falling back to the pool.
+func (c *Connection) querier() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	if c.conn != nil {
+		return c.conn
+	}
+	return c.db
+}
+
+// stmtFor returns a prepared statement for sqlText, preparing and caching it
+// on first use. The cache is scoped to this Connection value, so statements
+// prepared within a Transaction are re-prepared against the transaction and
+// discarded when it ends.
+func (c *Connection) stmtFor(ctx context.Context, sqlText string) (*sql.Stmt, error) {
+	c.stmtMu.Lock()
+	defer c.stmtMu.Unlock()
+
+	if stmt, ok := c.stmts[sqlText]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := c.querier().PrepareContext(ctx, sqlText)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[sqlText] = stmt
+	return stmt, nil
+}
+
+// Query executes a SQL query, binding args positionally, and returns each
+// result row as a column-name-to-value map.
+func (c *Connection) Query(sqlText string, args ...interface{}) ([]map[string]interface{}, error) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	connected := c.connected
+	c.mu.RUnlock()
 
-	if !c.connected {
+	if !connected {
 		return nil, ErrNotConnected
 	}
+	if sqlText == "" {
+		return nil, &DatabaseError{Message: "empty query", Query: sqlText}
+	}
+
+	ctx := context.Background()
+	if roled, release, err := c.checkoutDefaultRole(ctx); err != nil {
+		return nil, &DatabaseError{Message: err.Error(), Query: sqlText}
+	} else if roled != nil {
+		defer release()
+		return roled.Query(sqlText, args...)
+	}
+
+	var results []map[string]interface{}
+	err := c.withRetry(ctx, true, func(ctx context.Context) error {
+		stmt, err := c.stmtFor(ctx, sqlText)
+		if err != nil {
+			return err
+		}
+
+		rows, err := stmt.QueryContext(ctx, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		results, err = scanRows(rows)
+		return err
+	})
+	if err != nil {
+		return nil, &DatabaseError{Message: err.Error(), Query: sqlText}
+	}
+	return results, nil
+}
+
+// scanRows reads every row out of rows into a column-name-to-value map,
+// preserving each driver's native Go type for the scanned values.
+func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
 
-	if sql == "" {
-		return nil, &DatabaseError{
-			Message: "empty query",
-			Query:   sql,
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = values[i]
 		}
+		results = append(results, row)
 	}
+	return results, rows.Err()
+}
+
+// Execute runs a SQL statement, binding args positionally, and returns the
+// number of affected rows. It is retried on a transient failure only via
+// ExecuteIdempotent, since retrying a plain Execute risks applying a write
+// twice.
+func (c *Connection) Execute(sqlText string, args ...interface{}) (int64, error) {
+	return c.execute(context.Background(), false, sqlText, args...)
+}
+
+// ExecuteContext behaves like Execute but runs under ctx, so a caller's
+// cancellation or deadline bounds the statement (and, via RetryPolicy, each
+// individual retry attempt) instead of running unbounded in the background.
+func (c *Connection) ExecuteContext(ctx context.Context, sqlText string, args ...interface{}) (int64, error) {
+	return c.execute(ctx, false, sqlText, args...)
+}
 
-	// Simulated query result for fixture purposes.
-	return []string{fmt.Sprintf("row from: %s", sql)}, nil
+// ExecuteIdempotent behaves like Execute but allows the retry subsystem to
+// re-run the statement on a transient error. Only call this for statements
+// that are safe to apply more than once, such as upserts or statements
+// guarded by a unique constraint.
+func (c *Connection) ExecuteIdempotent(sqlText string, args ...interface{}) (int64, error) {
+	return c.execute(context.Background(), true, sqlText, args...)
 }
 
-// Execute runs a SQL statement and returns the number of affected rows.
-func (c *Connection) Execute(sql string, args ...interface{}) (int64, error) {
+// ExecuteIdempotentContext behaves like ExecuteIdempotent but runs under
+// ctx; see ExecuteContext.
+func (c *Connection) ExecuteIdempotentContext(ctx context.Context, sqlText string, args ...interface{}) (int64, error) {
+	return c.execute(ctx, true, sqlText, args...)
+}
+
+func (c *Connection) execute(ctx context.Context, idempotent bool, sqlText string, args ...interface{}) (int64, error) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	connected := c.connected
+	c.mu.RUnlock()
 
-	if !c.connected {
+	if !connected {
 		return 0, ErrNotConnected
 	}
 
-	_ = args // used for parameter binding in real implementation
-	return 1, nil
+	if roled, release, err := c.checkoutDefaultRole(ctx); err != nil {
+		return 0, &DatabaseError{Message: err.Error(), Query: sqlText}
+	} else if roled != nil {
+		defer release()
+		return roled.execute(ctx, idempotent, sqlText, args...)
+	}
+
+	var affected int64
+	err := c.withRetry(ctx, idempotent, func(ctx context.Context) error {
+		stmt, err := c.stmtFor(ctx, sqlText)
+		if err != nil {
+			return err
+		}
+
+		result, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, &DatabaseError{Message: err.Error(), Query: sqlText}
+	}
+	c.maybeAudit(ctx, "sql.execute", sqlText)
+	return affected, nil
 }
 
-// Transaction executes the given function within a database transaction.
-// The transaction is committed on success and rolled back on error.
+// Transaction executes the given function within a real database
+// transaction, issuing BEGIN before it runs and COMMIT or ROLLBACK
+// afterward depending on whether it returns an error. A transient failure
+// retries the whole attempt, since a rolled-back transaction has applied
+// nothing and is always safe to re-run.
 func (c *Connection) Transaction(fn func(*Connection) error) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.TransactionContext(context.Background(), fn)
+}
 
-	if !c.connected {
+// TransactionContext behaves like Transaction but runs under ctx, so a
+// caller's cancellation or deadline bounds BEGIN/COMMIT and every statement
+// fn runs against txConn, instead of running unbounded in the background.
+//
+// If c is already scoped to a transaction (because fn was itself called from
+// within an enclosing TransactionContext), TransactionContext does not open a
+// nested transaction: it runs fn directly against c, so the enclosing
+// transaction's BEGIN/COMMIT/ROLLBACK also governs statements issued here.
+func (c *Connection) TransactionContext(ctx context.Context, fn func(*Connection) error) error {
+	if c.tx != nil {
+		return fn(c)
+	}
+
+	c.mu.RLock()
+	connected := c.connected
+	c.mu.RUnlock()
+
+	if !connected {
 		return ErrNotConnected
 	}
 
-	// In a real implementation, BEGIN would be sent here.
-	if err := fn(c); err != nil {
-		// ROLLBACK
-		log.Printf("transaction rolled back: %v", err)
-		return fmt.Errorf("transaction failed: %w", err)
+	if roled, release, err := c.checkoutDefaultRole(ctx); err != nil {
+		return &DatabaseError{Message: err.Error()}
+	} else if roled != nil {
+		defer release()
+		return roled.TransactionContext(ctx, fn)
 	}
 
-	// COMMIT
-	return nil
+	return c.withRetry(ctx, true, func(ctx context.Context) error {
+		var tx *sql.Tx
+		var err error
+		if c.conn != nil {
+			tx, err = c.conn.BeginTx(ctx, nil)
+		} else {
+			tx, err = c.db.BeginTx(ctx, nil)
+		}
+		if err != nil {
+			return &DatabaseError{Message: fmt.Sprintf("beginning transaction: %v", err)}
+		}
+
+		c.mu.RLock()
+		retryPolicy := c.retryPolicy
+		c.mu.RUnlock()
+
+		txConn := &Connection{
+			url:         c.url,
+			driverName:  c.driverName,
+			db:          c.db,
+			tx:          tx,
+			poolSize:    c.poolSize,
+			connected:   true,
+			stmts:       make(map[string]*sql.Stmt),
+			retryPolicy: retryPolicy,
+			role:        c.role,
+			auditor:     c.auditor,
+			actorID:     c.actorID,
+		}
+
+		if err := fn(txConn); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback failed: %v", rbErr)
+			}
+			log.Printf("transaction rolled back: %v", err)
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return &DatabaseError{Message: fmt.Sprintf("committing transaction: %v", err)}
+		}
+		c.maybeAudit(ctx, "sql.transaction", "")
+		return nil
+	})
 }
 
 // Close terminates the database connection.
@@ -130,19 +402,37 @@ func (c *Connection) Close() error {
 	if !c.connected {
 		return nil
 	}
-
 	c.connected = false
+
+	c.stmtMu.Lock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmtMu.Unlock()
+
+	if c.tx != nil {
+		return nil
+	}
+
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+		return nil
+	}
+
+	if err := c.db.Close(); err != nil {
+		return &DatabaseError{Message: fmt.Sprintf("closing connection: %v", err)}
+	}
 	log.Println("database connection closed")
 	return nil
 }
 
-// RedactedURL returns the connection URL with credentials removed.
+// RedactedURL returns the connection URL with its password component
+// replaced by "REDACTED", safe to include in logs.
 func (c *Connection) RedactedURL() string {
-	// Naive redaction: remove everything before @.
-	for i, ch := range c.url {
-		if ch == '@' {
-			return c.url[i+1:]
-		}
+	redacted, err := dsn.Redact(c.url)
+	if err != nil {
+		return "<redacted: unparseable connection string>"
 	}
-	return c.url
+	return redacted
 }
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// driverNames maps a connection URL scheme to the database/sql driver name
+// it should be opened with.
+var driverNames = map[string]string{
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"mysql":      "mysql",
+	"sqlite":     "sqlite3",
+	"sqlite3":    "sqlite3",
+}
+
+// numberedPlaceholderDrivers is the set of database/sql driver names that
+// bind parameters positionally as $1, $2, ... rather than with a bare "?".
+var numberedPlaceholderDrivers = map[string]bool{
+	"postgres": true,
+}
+
+// Placeholders returns n comma-separated bind-parameter placeholders in the
+// syntax this Connection's driver expects: "$1, $2, $3" for Postgres, or
+// "?, ?, ?" for MySQL/SQLite. Callers building SQL text containing more than
+// one parameter, such as audit.TableSink, should use this instead of
+// hardcoding either syntax.
+func (c *Connection) Placeholders(n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		if numberedPlaceholderDrivers[c.driverName] {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		} else {
+			placeholders[i] = "?"
+		}
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// resolveDriver parses a connection URL and returns the database/sql driver
+// name and the driver-specific data source name derived from it.
+func resolveDriver(rawURL string) (driverName, dataSourceName string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing database URL: %w", err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	driverName, ok := driverNames[scheme]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported database scheme: %q", scheme)
+	}
+
+	// x-role is an application-level parameter consumed by parseDefaultRole;
+	// the real driver doesn't recognize it and Postgres rejects unknown
+	// startup parameters outright, so it must not reach sql.Open.
+	if u.RawQuery != "" {
+		query := u.Query()
+		query.Del("x-role")
+		u.RawQuery = query.Encode()
+	}
+
+	switch driverName {
+	case "mysql":
+		return driverName, mysqlDSN(u), nil
+	case "sqlite3":
+		return driverName, sqliteDSN(u), nil
+	default:
+		return driverName, u.String(), nil
+	}
+}
+
+// mysqlDSN converts a mysql:// URL into the go-sql-driver/mysql DSN format:
+// user:pass@tcp(host:port)/dbname?params
+func mysqlDSN(u *url.URL) string {
+	var dsn strings.Builder
+	if u.User != nil {
+		dsn.WriteString(u.User.String())
+		dsn.WriteByte('@')
+	}
+	dsn.WriteString("tcp(")
+	dsn.WriteString(u.Host)
+	dsn.WriteString(")")
+	dsn.WriteString(u.Path)
+	if u.RawQuery != "" {
+		dsn.WriteByte('?')
+		dsn.WriteString(u.RawQuery)
+	}
+	return dsn.String()
+}
+
+// sqliteDSN converts a sqlite:// URL into the filesystem path (or
+// ":memory:") that mattn/go-sqlite3 expects.
+func sqliteDSN(u *url.URL) string {
+	if u.Host == ":memory:" || u.Path == "" {
+		return ":memory:"
+	}
+	return u.Path
+}
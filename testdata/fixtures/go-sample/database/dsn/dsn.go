@@ -0,0 +1,185 @@
+// Package dsn parses and redacts database connection strings across the
+// driver formats this codebase supports: Postgres/MySQL/SQLite URLs,
+// go-sql-driver/mysql's user:pass@tcp(host:port)/db DSN, and SQL Server's
+// key=value;key=value connection strings.
+package dsn
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DSN is a parsed connection string with its credential, host, and option
+// components split out.
+type DSN struct {
+	Scheme   string
+	Username string
+	Password string
+	Host     string
+	Path     string
+	Query    string
+	// KVPairs holds the original "key=value" segments for SQL Server-style
+	// connection strings, in order. Empty for URL and MySQL DSN formats.
+	KVPairs []string
+
+	format string // "url", "mysql", or "kv"; selects how String reconstructs the DSN
+}
+
+// mysqlDSNPattern matches go-sql-driver/mysql's
+// [username[:password]@]tcp(host:port)[/dbname][?params] format.
+var mysqlDSNPattern = regexp.MustCompile(`^(?:([^:@]*)(?::([^@]*))?@)?tcp\(([^)]*)\)(/[^?]*)?(?:\?(.*))?$`)
+
+// Parse parses a connection string in any of the supported driver formats.
+func Parse(raw string) (*DSN, error) {
+	switch {
+	case strings.Contains(raw, "://"):
+		return parseURL(raw)
+	case strings.Contains(raw, "@tcp(") || strings.HasPrefix(raw, "tcp("):
+		return parseMySQLDSN(raw)
+	case strings.Contains(raw, "="):
+		return parseKeyValue(raw)
+	default:
+		return nil, fmt.Errorf("dsn: unrecognized connection string format")
+	}
+}
+
+func parseURL(raw string) (*DSN, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("dsn: parsing URL: %w", err)
+	}
+
+	d := &DSN{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   u.Path,
+		Query:  u.RawQuery,
+		format: "url",
+	}
+	if u.User != nil {
+		d.Username = u.User.Username()
+		d.Password, _ = u.User.Password()
+	}
+	return d, nil
+}
+
+func parseMySQLDSN(raw string) (*DSN, error) {
+	m := mysqlDSNPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return nil, fmt.Errorf("dsn: unrecognized mysql DSN format")
+	}
+	return &DSN{
+		Scheme:   "mysql",
+		Username: m[1],
+		Password: m[2],
+		Host:     m[3],
+		Path:     m[4],
+		Query:    m[5],
+		format:   "mysql",
+	}, nil
+}
+
+func parseKeyValue(raw string) (*DSN, error) {
+	d := &DSN{Scheme: "sqlserver", format: "kv"}
+
+	pairs := strings.Split(raw, ";")
+	kept := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kept = append(kept, pair)
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "password", "pwd":
+			d.Password = value
+		case "user id", "uid", "user":
+			d.Username = value
+		case "server", "data source":
+			d.Host = value
+		case "database", "initial catalog":
+			d.Path = value
+		}
+	}
+	d.KVPairs = kept
+	return d, nil
+}
+
+// String reconstructs the connection string in its original format.
+func (d *DSN) String() string {
+	switch d.format {
+	case "mysql":
+		return d.mysqlString()
+	case "kv":
+		return strings.Join(d.KVPairs, ";")
+	default:
+		return d.urlString()
+	}
+}
+
+func (d *DSN) urlString() string {
+	u := &url.URL{Scheme: d.Scheme, Host: d.Host, Path: d.Path, RawQuery: d.Query}
+	switch {
+	case d.Password != "":
+		u.User = url.UserPassword(d.Username, d.Password)
+	case d.Username != "":
+		u.User = url.User(d.Username)
+	}
+	return u.String()
+}
+
+func (d *DSN) mysqlString() string {
+	var b strings.Builder
+	if d.Username != "" || d.Password != "" {
+		b.WriteString(d.Username)
+		if d.Password != "" {
+			b.WriteByte(':')
+			b.WriteString(d.Password)
+		}
+		b.WriteByte('@')
+	}
+	b.WriteString("tcp(")
+	b.WriteString(d.Host)
+	b.WriteString(")")
+	b.WriteString(d.Path)
+	if d.Query != "" {
+		b.WriteByte('?')
+		b.WriteString(d.Query)
+	}
+	return b.String()
+}
+
+// Redact returns raw with its password component replaced by "REDACTED",
+// leaving username, host, port, dbname, and other options intact. This
+// makes a connection string safe to write to logs.
+func Redact(raw string) (string, error) {
+	d, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if d.Password != "" {
+		d.Password = "REDACTED"
+	}
+	if d.format == "kv" {
+		for i, pair := range d.KVPairs {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || value == "" {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "password", "pwd":
+				d.KVPairs[i] = key + "=REDACTED"
+			}
+		}
+	}
+
+	return d.String(), nil
+}
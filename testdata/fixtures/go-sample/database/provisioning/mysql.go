@@ -0,0 +1,103 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-sample/database"
+	"go-sample/models"
+)
+
+// MySQL provisions database users using MySQL DDL. Each entry in Schemas is
+// a database name; MySQL has no separate schema/database concept.
+type MySQL struct {
+	Schemas []string
+}
+
+var _ Provisioner = MySQL{}
+
+// CreateUser implements Provisioner.
+func (m MySQL) CreateUser(ctx context.Context, conn *database.Connection, user *models.User) error {
+	account := mysqlAccount(user)
+
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("CREATE USER %s", account)); err != nil {
+			return fmt.Errorf("creating user: %w", err)
+		}
+		return m.grant(ctx, tx, account, user.Role)
+	})
+}
+
+// DropUser implements Provisioner.
+func (m MySQL) DropUser(ctx context.Context, conn *database.Connection, user *models.User) error {
+	account := mysqlAccount(user)
+
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("DROP USER %s", account)); err != nil {
+			return fmt.Errorf("dropping user: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetLogin implements Provisioner.
+func (m MySQL) SetLogin(ctx context.Context, conn *database.Connection, user *models.User, canLogin bool) error {
+	account := mysqlAccount(user)
+	action := "ACCOUNT LOCK"
+	if canLogin {
+		action = "ACCOUNT UNLOCK"
+	}
+	if _, err := conn.ExecuteContext(ctx, fmt.Sprintf("ALTER USER %s %s", account, action)); err != nil {
+		return fmt.Errorf("setting %s on user: %w", action, err)
+	}
+	return nil
+}
+
+// Regrant implements Provisioner.
+func (m MySQL) Regrant(ctx context.Context, conn *database.Connection, user *models.User, newRole models.Role) error {
+	account := mysqlAccount(user)
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if err := m.revoke(ctx, tx, account); err != nil {
+			return err
+		}
+		return m.grant(ctx, tx, account, newRole)
+	})
+}
+
+// grant issues GRANT statements for account across m.Schemas, including
+// WITH GRANT OPTION when forRole is RoleAdmin.
+func (m MySQL) grant(ctx context.Context, tx *database.Connection, account string, forRole models.Role) error {
+	grantOption := ""
+	if forRole == models.RoleAdmin {
+		grantOption = " WITH GRANT OPTION"
+	}
+	privileges := strings.Join(SchemaPrivileges, ", ")
+
+	for _, schema := range m.Schemas {
+		quoted := database.MySQLQuoteIdentifier(schema)
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("GRANT CREATE, %s ON %s.* TO %s%s", privileges, quoted, account, grantOption)); err != nil {
+			return fmt.Errorf("granting privileges: %w", err)
+		}
+	}
+	return nil
+}
+
+// revoke strips account of every privilege it may hold across m.Schemas.
+func (m MySQL) revoke(ctx context.Context, tx *database.Connection, account string) error {
+	for _, schema := range m.Schemas {
+		quoted := database.MySQLQuoteIdentifier(schema)
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("REVOKE ALL PRIVILEGES ON %s.* FROM %s", quoted, account)); err != nil {
+			return fmt.Errorf("revoking privileges: %w", err)
+		}
+	}
+	return nil
+}
+
+// mysqlAccount returns the `'user'@'%'` account specifier for user, escaping
+// a single quote in the role name the same way database.QuoteIdentifier
+// escapes Postgres identifiers, to prevent injection through user.ID.
+func mysqlAccount(user *models.User) string {
+	escaped := strings.ReplaceAll(roleName(user), "'", "''")
+	return fmt.Sprintf("'%s'@'%%'", escaped)
+}
@@ -0,0 +1,104 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-sample/database"
+	"go-sample/models"
+)
+
+// Postgres provisions database roles using Postgres/CockroachDB DDL.
+type Postgres struct {
+	// Schemas lists the schemas a provisioned role is granted access to.
+	Schemas []string
+}
+
+var _ Provisioner = Postgres{}
+
+// CreateUser implements Provisioner.
+func (p Postgres) CreateUser(ctx context.Context, conn *database.Connection, user *models.User) error {
+	role := database.QuoteIdentifier(roleName(user))
+
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("CREATE ROLE %s LOGIN", role)); err != nil {
+			return fmt.Errorf("creating role: %w", err)
+		}
+		return p.grant(ctx, tx, role, user.Role)
+	})
+}
+
+// DropUser implements Provisioner.
+func (p Postgres) DropUser(ctx context.Context, conn *database.Connection, user *models.User) error {
+	role := database.QuoteIdentifier(roleName(user))
+
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if err := p.revoke(ctx, tx, role); err != nil {
+			return err
+		}
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("DROP ROLE %s", role)); err != nil {
+			return fmt.Errorf("dropping role: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetLogin implements Provisioner.
+func (p Postgres) SetLogin(ctx context.Context, conn *database.Connection, user *models.User, canLogin bool) error {
+	role := database.QuoteIdentifier(roleName(user))
+	state := "NOLOGIN"
+	if canLogin {
+		state = "LOGIN"
+	}
+	if _, err := conn.ExecuteContext(ctx, fmt.Sprintf("ALTER ROLE %s %s", role, state)); err != nil {
+		return fmt.Errorf("setting %s on role: %w", state, err)
+	}
+	return nil
+}
+
+// Regrant implements Provisioner.
+func (p Postgres) Regrant(ctx context.Context, conn *database.Connection, user *models.User, newRole models.Role) error {
+	role := database.QuoteIdentifier(roleName(user))
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		if err := p.revoke(ctx, tx, role); err != nil {
+			return err
+		}
+		return p.grant(ctx, tx, role, newRole)
+	})
+}
+
+// grant issues GRANT statements for role across p.Schemas, including
+// WITH GRANT OPTION when forRole is RoleAdmin.
+func (p Postgres) grant(ctx context.Context, tx *database.Connection, role string, forRole models.Role) error {
+	grantOption := ""
+	if forRole == models.RoleAdmin {
+		grantOption = " WITH GRANT OPTION"
+	}
+	privileges := strings.Join(SchemaPrivileges, ", ")
+
+	for _, schema := range p.Schemas {
+		quoted := database.QuoteIdentifier(schema)
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("GRANT CREATE, USAGE ON SCHEMA %s TO %s%s", quoted, role, grantOption)); err != nil {
+			return fmt.Errorf("granting schema usage: %w", err)
+		}
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("GRANT %s ON ALL TABLES IN SCHEMA %s TO %s%s", privileges, quoted, role, grantOption)); err != nil {
+			return fmt.Errorf("granting table privileges: %w", err)
+		}
+	}
+	return nil
+}
+
+// revoke strips role of every privilege it may hold across p.Schemas.
+func (p Postgres) revoke(ctx context.Context, tx *database.Connection, role string) error {
+	for _, schema := range p.Schemas {
+		quoted := database.QuoteIdentifier(schema)
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("REVOKE ALL PRIVILEGES ON ALL TABLES IN SCHEMA %s FROM %s", quoted, role)); err != nil {
+			return fmt.Errorf("revoking table privileges: %w", err)
+		}
+		if _, err := tx.ExecuteContext(ctx, fmt.Sprintf("REVOKE ALL PRIVILEGES ON SCHEMA %s FROM %s", quoted, role)); err != nil {
+			return fmt.Errorf("revoking schema privileges: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package provisioning auto-provisions database roles and grants in
+// response to models.User lifecycle events (creation, deactivation,
+// promotion), keeping database-level privileges in sync with the
+// application's view of a user's role.
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"go-sample/audit"
+	"go-sample/database"
+	"go-sample/models"
+)
+
+// SchemaPrivileges is the standard table privilege set granted to a
+// provisioned role within each of its schemas.
+var SchemaPrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE"}
+
+// Provisioner issues the DDL needed to keep a database role in sync with a
+// models.User's lifecycle. Postgres and MySQL implementations are provided;
+// both run the DDL of CreateUser, DropUser and Regrant inside
+// conn.TransactionContext, while SetLogin issues its single statement
+// directly on conn.
+type Provisioner interface {
+	// CreateUser provisions a LOGIN-capable role for a newly created user
+	// and grants it the privilege set appropriate for user.Role.
+	CreateUser(ctx context.Context, conn *database.Connection, user *models.User) error
+	// DropUser revokes all grants and removes the role.
+	DropUser(ctx context.Context, conn *database.Connection, user *models.User) error
+	// SetLogin toggles whether the role can be used to log in, used when a
+	// user is deactivated or reactivated.
+	SetLogin(ctx context.Context, conn *database.Connection, user *models.User, canLogin bool) error
+	// Regrant replaces the role's grants with the set appropriate for
+	// newRole, used when a user is promoted or demoted.
+	Regrant(ctx context.Context, conn *database.Connection, user *models.User, newRole models.Role) error
+}
+
+// roleName derives the database role name for a user. It is namespaced by
+// user ID so it stays stable across username changes.
+func roleName(user *models.User) string {
+	return fmt.Sprintf("app_user_%s", user.ID)
+}
+
+// OnUserCreated provisions a database role for a newly created user.
+func OnUserCreated(ctx context.Context, p Provisioner, conn *database.Connection, user *models.User) error {
+	return p.CreateUser(ctx, conn, user)
+}
+
+// OnUserDeactivated revokes login from the user's role after
+// models.User.Deactivate.
+func OnUserDeactivated(ctx context.Context, p Provisioner, conn *database.Connection, user *models.User) error {
+	return p.SetLogin(ctx, conn, user, false)
+}
+
+// OnUserReactivated restores login to the user's role.
+func OnUserReactivated(ctx context.Context, p Provisioner, conn *database.Connection, user *models.User) error {
+	return p.SetLogin(ctx, conn, user, true)
+}
+
+// OnUserPromoted regrants privileges for the user's new role after
+// models.User.Promote.
+func OnUserPromoted(ctx context.Context, p Provisioner, conn *database.Connection, user *models.User) error {
+	return p.Regrant(ctx, conn, user, user.Role)
+}
+
+// auditorForTx rebinds auditor onto tx when it is a *audit.TableSink, so its
+// INSERT runs against the same transaction as the DDL PromoteUser and
+// DeactivateUser issue through tx, and the two commit or roll back together.
+// Any other Auditor implementation (e.g. audit.FileSink) is returned as-is,
+// since it has no connection to rebind.
+func auditorForTx(auditor audit.Auditor, tx *database.Connection) audit.Auditor {
+	if sink, ok := auditor.(*audit.TableSink); ok {
+		return sink.WithConn(tx)
+	}
+	return auditor
+}
+
+// PromoteUser promotes user to newRole and regrants its database privileges
+// for the new role in one call, both inside a single transaction. When
+// auditor is an *audit.TableSink, the audit write (via user.Promote, which
+// refuses the promotion if that fails) and the Regrant DDL (via
+// OnUserPromoted) commit or roll back together, so a promotion can never be
+// recorded without the privilege change taking effect, or vice versa. Any
+// other Auditor (e.g. audit.FileSink) writes its record outside the
+// transaction, and that record stays even if the transaction rolls back.
+//
+// A transient failure (e.g. a serialization failure at COMMIT) re-runs the
+// whole attempt, so user.Role is reset to its pre-promotion value at the
+// start of every attempt; otherwise a retry would promote from the already-
+// promoted role and record a BeforeRole/AfterRole audit entry that doesn't
+// reflect the user's real prior role.
+func PromoteUser(ctx context.Context, p Provisioner, conn *database.Connection, auditor audit.Auditor, actor string, user *models.User, newRole models.Role) error {
+	priorRole := user.Role
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		user.Role = priorRole
+		if err := user.Promote(ctx, auditorForTx(auditor, tx), actor, newRole); err != nil {
+			return err
+		}
+		return OnUserPromoted(ctx, p, tx, user)
+	})
+}
+
+// DeactivateUser deactivates user and revokes login from its database role
+// in one call, analogous to PromoteUser: the SetLogin(false) call, and the
+// audit write when auditor is an *audit.TableSink, run inside the same
+// transaction, and user.Active is
+// reset at the start of every retry attempt for the same reason user.Role
+// is reset in PromoteUser.
+func DeactivateUser(ctx context.Context, p Provisioner, conn *database.Connection, auditor audit.Auditor, actor string, user *models.User) error {
+	priorActive := user.Active
+	return conn.TransactionContext(ctx, func(tx *database.Connection) error {
+		user.Active = priorActive
+		if err := user.Deactivate(ctx, auditorForTx(auditor, tx), actor); err != nil {
+			return err
+		}
+		return OnUserDeactivated(ctx, p, tx, user)
+	})
+}
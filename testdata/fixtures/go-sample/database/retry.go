@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures the exponential backoff applied to transient
+// failures by Query, Execute, and Transaction.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// BaseDelay is the backoff delay before the second attempt; it doubles
+	// on each subsequent attempt up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay regardless of attempt count.
+	MaxDelay time.Duration
+	// AttemptTimeout bounds each individual attempt via its context
+	// deadline. Zero means no per-attempt deadline.
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy returns the policy a new Connection starts with.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    maxRetries + 1, // the first attempt plus maxRetries retries
+		BaseDelay:      50 * time.Millisecond,
+		MaxDelay:       2 * time.Second,
+		AttemptTimeout: 5 * time.Second,
+	}
+}
+
+// normalizeMaxAttempts clamps MaxAttempts to at least 1, so a zero-value or
+// misconfigured RetryPolicy still runs fn once instead of silently skipping
+// every query.
+func normalizeMaxAttempts(maxAttempts int) int {
+	if maxAttempts < 1 {
+		return 1
+	}
+	return maxAttempts
+}
+
+// transientSQLStates are driver-reported SQLSTATE/error codes that indicate
+// a condition worth retrying: Postgres serialization_failure (40001) and
+// deadlock_detected (40P01), MySQL deadlock (1213) and lock-wait-timeout
+// (1205).
+var transientSQLStates = []string{"40001", "40P01", "1213", "1205"}
+
+// transientSubstrings catches common transport-level failures that don't
+// carry a SQLSTATE.
+var transientSubstrings = []string{
+	"connection reset",
+	"broken pipe",
+	"connection refused",
+	"driver: bad connection",
+}
+
+// IsTransient reports whether err represents a transient condition that is
+// safe to retry.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrPoolExhausted) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, code := range transientSQLStates {
+		if strings.Contains(msg, code) {
+			return true
+		}
+	}
+	for _, substr := range transientSubstrings {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry runs fn, retrying on transient errors according to c's
+// RetryPolicy when idempotent is true. Non-idempotent callers get exactly
+// one attempt, since retrying risks applying a write twice.
+func (c *Connection) withRetry(ctx context.Context, idempotent bool, fn func(context.Context) error) error {
+	c.mu.RLock()
+	policy := c.retryPolicy
+	c.mu.RUnlock()
+	maxAttempts := normalizeMaxAttempts(policy.MaxAttempts)
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		attemptCtx := ctx
+		var cancel context.CancelFunc
+		if policy.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.AttemptTimeout)
+		}
+		err := fn(attemptCtx)
+		if cancel != nil {
+			cancel()
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !idempotent || !IsTransient(err) || attempt == maxAttempts-1 {
+			return err
+		}
+
+		select {
+		case <-time.After(backoffDelay(policy, attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// backoffDelay returns the delay before the attempt following attempt,
+// doubling BaseDelay each time and adding jitter to avoid thundering-herd
+// retries, capped at MaxDelay.
+func backoffDelay(policy RetryPolicy, attempt int) time.Duration {
+	delay := policy.BaseDelay << uint(attempt)
+	if delay <= 0 || delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) + 1))
+	return delay/2 + jitter/2
+}
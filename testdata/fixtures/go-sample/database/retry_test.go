@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int
+		want int
+	}{
+		{"zero value", 0, 1},
+		{"negative", -3, 1},
+		{"already valid", 5, 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeMaxAttempts(tc.in); got != tc.want {
+				t.Errorf("normalizeMaxAttempts(%d) = %d, want %d", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"pool exhausted", ErrPoolExhausted, true},
+		{"wrapped pool exhausted", fmt.Errorf("query: %w", ErrPoolExhausted), true},
+		{"postgres serialization failure", errors.New("pq: code 40001 serialization_failure"), true},
+		{"mysql deadlock", errors.New("Error 1213: Deadlock found"), true},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"unrelated error", errors.New("column \"foo\" does not exist"), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsTransient(tc.err); got != tc.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 500 * time.Millisecond}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := backoffDelay(policy, attempt)
+		if delay < 0 || delay > policy.MaxDelay {
+			t.Errorf("backoffDelay(attempt=%d) = %v, want within [0, %v]", attempt, delay, policy.MaxDelay)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"go-sample/models"
+)
+
+// quoteIdentifier quotes a SQL identifier (a role or schema name) using
+// double-quote escaping, matching Postgres/CockroachDB and standard SQL
+// identifier syntax, to prevent injection through role names.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteIdentifier quotes a SQL identifier, such as a role or schema name,
+// using double-quote escaping. It is exported for packages such as
+// database/provisioning that build DDL referencing these names.
+func QuoteIdentifier(name string) string {
+	return quoteIdentifier(name)
+}
+
+// mysqlQuoteIdentifier quotes a MySQL identifier using backtick escaping.
+// Unlike Postgres, stock MySQL (without ANSI_QUOTES) treats a double-quoted
+// token as a string literal rather than an identifier, so quoteIdentifier's
+// escaping doesn't apply here.
+func mysqlQuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// MySQLQuoteIdentifier quotes a MySQL identifier, such as a database or
+// schema name, using backtick escaping. It is exported for packages such as
+// database/provisioning that build MySQL DDL referencing these names.
+func MySQLQuoteIdentifier(name string) string {
+	return mysqlQuoteIdentifier(name)
+}
+
+// setRoleStatement returns the statement that sets role as the active role
+// on a single connection, in the syntax driverName's dialect expects.
+func setRoleStatement(driverName string, role models.Role) string {
+	if driverName == "mysql" {
+		return fmt.Sprintf("SET ROLE %s", mysqlQuoteIdentifier(string(role)))
+	}
+	return fmt.Sprintf("SET ROLE %s", quoteIdentifier(string(role)))
+}
+
+// resetRoleStatement returns the statement that clears a role set by
+// setRoleStatement. MySQL has no RESET ROLE statement; SET ROLE NONE is its
+// equivalent.
+func resetRoleStatement(driverName string) string {
+	if driverName == "mysql" {
+		return "SET ROLE NONE"
+	}
+	return "RESET ROLE"
+}
+
+// parseDefaultRole reads the x-role query parameter from a connection URL,
+// if present, so NewConnection can set a default role applied to every
+// checkout from the pool.
+func parseDefaultRole(rawURL string) (models.Role, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing database URL: %w", err)
+	}
+
+	raw := u.Query().Get("x-role")
+	if raw == "" {
+		return "", nil
+	}
+
+	role, err := models.ParseRole(raw)
+	if err != nil {
+		return "", fmt.Errorf("parsing x-role: %w", err)
+	}
+	return role, nil
+}
+
+// WithRole checks out a single connection from the pool, issues
+// SET ROLE <role> on it, and returns a Connection pinned to that connection
+// so every query run through it executes under role's database privileges.
+// Call Release once you're done with it to RESET ROLE and return the
+// connection to the pool.
+func (c *Connection) WithRole(ctx context.Context, role models.Role) (*Connection, error) {
+	if !role.IsValid() {
+		return nil, &DatabaseError{Message: fmt.Sprintf("unknown role: %q", role)}
+	}
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, &DatabaseError{Message: fmt.Sprintf("checking out connection: %v", err)}
+	}
+
+	setRoleSQL := setRoleStatement(c.driverName, role)
+	if _, err := conn.ExecContext(ctx, setRoleSQL); err != nil {
+		conn.Close()
+		return nil, &DatabaseError{Message: fmt.Sprintf("setting session role: %v", err), Query: setRoleSQL}
+	}
+
+	c.mu.RLock()
+	retryPolicy := c.retryPolicy
+	c.mu.RUnlock()
+
+	return &Connection{
+		url:         c.url,
+		driverName:  c.driverName,
+		db:          c.db,
+		conn:        conn,
+		role:        role,
+		poolSize:    c.poolSize,
+		connected:   true,
+		stmts:       make(map[string]*sql.Stmt),
+		retryPolicy: retryPolicy,
+		auditor:     c.auditor,
+		actorID:     c.actorID,
+	}, nil
+}
+
+// Release clears the role pinned by WithRole and returns the connection to
+// the pool. It is a no-op on a Connection that was not obtained from
+// WithRole.
+//
+// If clearing the role fails, the connection still carries role's elevated
+// privileges at the session level. Returning it to the pool in that state
+// would silently hand those privileges to whatever the next checkout runs,
+// so Release discards the physical connection instead of recycling it.
+func (c *Connection) Release(ctx context.Context) error {
+	if c.conn == nil {
+		return nil
+	}
+
+	resetSQL := resetRoleStatement(c.driverName)
+	_, resetErr := c.conn.ExecContext(ctx, resetSQL)
+	if resetErr != nil {
+		log.Printf("resetting session role failed: %v", resetErr)
+	}
+
+	c.stmtMu.Lock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmtMu.Unlock()
+
+	var closeErr error
+	if resetErr != nil {
+		if rawErr := c.conn.Raw(func(driverConn interface{}) error {
+			return driver.ErrBadConn
+		}); rawErr != nil && !errors.Is(rawErr, driver.ErrBadConn) {
+			closeErr = rawErr
+		}
+	} else {
+		closeErr = c.conn.Close()
+	}
+	c.conn = nil
+
+	if resetErr != nil {
+		return &DatabaseError{Message: fmt.Sprintf("resetting session role: %v", resetErr)}
+	}
+	if closeErr != nil {
+		return &DatabaseError{Message: fmt.Sprintf("releasing connection: %v", closeErr)}
+	}
+	return nil
+}
+
+// checkoutDefaultRole checks out a role-scoped Connection for a single
+// Query/Execute/Transaction call when this Connection has a default role configured
+// (via the x-role URL parameter) and isn't already scoped to a transaction
+// or a pinned connection. roled is nil when no role override applies. The
+// returned release func must be called (typically via defer) once the
+// caller is done with roled.
+func (c *Connection) checkoutDefaultRole(ctx context.Context) (roled *Connection, release func(), err error) {
+	if c.defaultRole == "" || c.tx != nil || c.conn != nil {
+		return nil, nil, nil
+	}
+
+	roled, err = c.WithRole(ctx, c.defaultRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roled, func() {
+		if err := roled.Release(ctx); err != nil {
+			log.Printf("releasing default-role connection: %v", err)
+		}
+	}, nil
+}
@@ -2,11 +2,36 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// Auditor records a privileged change to a User. Implementations must be
+// safe for concurrent use.
+//
+// This interface (and AuditRecord) lives here rather than in package audit
+// so that Promote and Deactivate can require one without this package
+// importing audit - which would be an import cycle, since audit imports
+// this package. Package audit defines Auditor and Record as aliases of these
+// types, so its sinks, written in terms of audit.Record, implement this
+// interface.
+type Auditor interface {
+	Record(ctx context.Context, rec AuditRecord) error
+}
+
+// AuditRecord describes a single privileged operation performed on a User.
+type AuditRecord struct {
+	Actor          string // ID of the user or service performing the action
+	Action         string // e.g. "user.promote", "user.deactivate"
+	Target         string // ID of the user acted upon; for SQL actions, the database role the statement ran under
+	BeforeRole     string
+	AfterRole      string
+	SQLFingerprint string // statement with literals stripped, for SQL actions
+	Timestamp      time.Time
+}
+
 // Role represents a user's permission level.
 type Role string
 
@@ -69,18 +94,59 @@ func NewUser(id, username, email string) *User {
 	}
 }
 
-// Deactivate marks the user account as inactive.
-func (u *User) Deactivate() {
+// Deactivate marks the user account as inactive. auditor must not be nil:
+// the deactivation is refused, and never applied, if it can't be recorded,
+// so a deactivation can never happen without a corresponding audit entry.
+func (u *User) Deactivate(ctx context.Context, auditor Auditor, actor string) error {
+	if auditor == nil {
+		return fmt.Errorf("models: refusing deactivation: no auditor configured")
+	}
+
+	wasActive := u.Active
 	u.Active = false
+
+	rec := AuditRecord{
+		Actor:      actor,
+		Action:     "user.deactivate",
+		Target:     u.ID,
+		BeforeRole: string(u.Role),
+		AfterRole:  string(u.Role),
+		Timestamp:  time.Now(),
+	}
+	if err := auditor.Record(ctx, rec); err != nil {
+		u.Active = wasActive
+		return fmt.Errorf("models: refusing deactivation: recording failed: %w", err)
+	}
+	return nil
 }
 
-// Promote changes the user's role if the new role is higher.
-// Returns an error if the new role would be a demotion.
-func (u *User) Promote(newRole Role) error {
+// Promote changes the user's role if the new role is higher, returning an
+// error if the new role would be a demotion. auditor must not be nil: the
+// promotion is refused, and never applied, if it can't be recorded, so a
+// promotion can never happen without a corresponding audit entry.
+func (u *User) Promote(ctx context.Context, auditor Auditor, actor string, newRole Role) error {
+	if auditor == nil {
+		return fmt.Errorf("models: refusing promotion: no auditor configured")
+	}
 	if !newRole.HasPermission(u.Role) {
 		return fmt.Errorf("cannot demote from %s to %s", u.Role, newRole)
 	}
+
+	before := u.Role
 	u.Role = newRole
+
+	rec := AuditRecord{
+		Actor:      actor,
+		Action:     "user.promote",
+		Target:     u.ID,
+		BeforeRole: string(before),
+		AfterRole:  string(u.Role),
+		Timestamp:  time.Now(),
+	}
+	if err := auditor.Record(ctx, rec); err != nil {
+		u.Role = before
+		return fmt.Errorf("models: refusing promotion: recording failed: %w", err)
+	}
 	return nil
 }
 
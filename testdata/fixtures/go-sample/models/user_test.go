@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeAuditor records whether Record was called and can be made to fail.
+type fakeAuditor struct {
+	err      error
+	recorded []AuditRecord
+}
+
+func (f *fakeAuditor) Record(ctx context.Context, rec AuditRecord) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.recorded = append(f.recorded, rec)
+	return nil
+}
+
+func TestPromoteRequiresAuditor(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+
+	if err := u.Promote(context.Background(), nil, "admin", RoleModerator); err == nil {
+		t.Fatal("Promote with nil auditor: expected error, got nil")
+	}
+	if u.Role != RoleUser {
+		t.Errorf("Role = %q after refused promotion, want unchanged %q", u.Role, RoleUser)
+	}
+}
+
+func TestPromoteRefusedWhenAuditFails(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+	auditor := &fakeAuditor{err: errors.New("sink unavailable")}
+
+	if err := u.Promote(context.Background(), auditor, "admin", RoleModerator); err == nil {
+		t.Fatal("Promote with failing auditor: expected error, got nil")
+	}
+	if u.Role != RoleUser {
+		t.Errorf("Role = %q after refused promotion, want rolled back to %q", u.Role, RoleUser)
+	}
+}
+
+func TestPromoteAppliesWhenAuditSucceeds(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+	auditor := &fakeAuditor{}
+
+	if err := u.Promote(context.Background(), auditor, "admin", RoleModerator); err != nil {
+		t.Fatalf("Promote: unexpected error: %v", err)
+	}
+	if u.Role != RoleModerator {
+		t.Errorf("Role = %q, want %q", u.Role, RoleModerator)
+	}
+	if len(auditor.recorded) != 1 {
+		t.Fatalf("recorded %d records, want 1", len(auditor.recorded))
+	}
+}
+
+func TestPromoteRejectsDemotion(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+	u.Role = RoleModerator
+	auditor := &fakeAuditor{}
+
+	if err := u.Promote(context.Background(), auditor, "admin", RoleUser); err == nil {
+		t.Fatal("Promote to a lower role: expected error, got nil")
+	}
+	if u.Role != RoleModerator {
+		t.Errorf("Role = %q after refused demotion, want unchanged %q", u.Role, RoleModerator)
+	}
+}
+
+func TestDeactivateRequiresAuditor(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+
+	if err := u.Deactivate(context.Background(), nil, "admin"); err == nil {
+		t.Fatal("Deactivate with nil auditor: expected error, got nil")
+	}
+	if !u.Active {
+		t.Error("Active = false after refused deactivation, want unchanged true")
+	}
+}
+
+func TestDeactivateRefusedWhenAuditFails(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+	auditor := &fakeAuditor{err: errors.New("sink unavailable")}
+
+	if err := u.Deactivate(context.Background(), auditor, "admin"); err == nil {
+		t.Fatal("Deactivate with failing auditor: expected error, got nil")
+	}
+	if !u.Active {
+		t.Error("Active = false after refused deactivation, want rolled back to true")
+	}
+}
+
+func TestDeactivateAppliesWhenAuditSucceeds(t *testing.T) {
+	u := NewUser("u1", "alice", "alice@example.com")
+	auditor := &fakeAuditor{}
+
+	if err := u.Deactivate(context.Background(), auditor, "admin"); err != nil {
+		t.Fatalf("Deactivate: unexpected error: %v", err)
+	}
+	if u.Active {
+		t.Error("Active = true after successful deactivation, want false")
+	}
+}